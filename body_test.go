@@ -0,0 +1,74 @@
+package request
+
+import (
+    "testing"
+    . "github.com/onsi/gomega"
+    . "github.com/franela/goblin"
+    "net/http/httptest"
+    "net/http"
+    "net/url"
+    "strings"
+    "io/ioutil"
+)
+
+func TestBody(t *testing.T) {
+    g := Goblin(t)
+
+    RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+    g.Describe("Request body encoding", func() {
+        var ts *httptest.Server
+        var gotContentType string
+        var gotForm url.Values
+
+        g.Before(func() {
+            ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                gotContentType = r.Header.Get("Content-Type")
+                r.ParseMultipartForm(10 << 20)
+                if r.MultipartForm != nil {
+                    gotForm = url.Values(r.MultipartForm.Value)
+                    for name, headers := range r.MultipartForm.File {
+                        for _, h := range headers {
+                            f, _ := h.Open()
+                            data, _ := ioutil.ReadAll(f)
+                            w.Header().Add("X-File-"+name, string(data))
+                        }
+                    }
+                } else {
+                    r.ParseForm()
+                    gotForm = r.PostForm
+                }
+                w.WriteHeader(200)
+            }))
+        })
+
+        g.After(func() {
+            ts.Close()
+        })
+
+        g.It("Should send a form-encoded body", func() {
+            res, err := Request{ Method: "POST", Uri: ts.URL, Body: map[string]string{"foo": "bar"} }.AsForm().Do()
+
+            Expect(err).Should(BeNil())
+            Expect(res.StatusCode).Should(Equal(200))
+            Expect(gotContentType).Should(Equal(ContentTypeForm))
+            Expect(gotForm.Get("foo")).Should(Equal("bar"))
+        })
+
+        g.It("Should send a multipart body with a file upload", func() {
+            res, err := Request{
+                Method: "POST",
+                Uri:    ts.URL,
+                Body:   map[string]string{"title": "hello"},
+                Files: []FileField{
+                    {Name: "upload", Filename: "hello.txt", Reader: strings.NewReader("file contents")},
+                },
+            }.AsMultipart().Do()
+
+            Expect(err).Should(BeNil())
+            Expect(res.StatusCode).Should(Equal(200))
+            Expect(gotForm.Get("title")).Should(Equal("hello"))
+            Expect(res.Header.Get("X-File-upload")).Should(Equal("file contents"))
+        })
+    })
+}
@@ -0,0 +1,158 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Client is a reusable alternative to the package-level Request{}.Do(): it
+// owns its own *http.Transport, so callers can isolate connection pools
+// per caller and shut them down cleanly with CloseIdleConnections instead
+// of sharing the implicit package-level transport.
+type Client struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+
+	once      sync.Once
+	transport *http.Transport
+}
+
+// DefaultClient is the Client used by the package-level Request{}.Do().
+var DefaultClient = &Client{}
+
+func (c *Client) httpTransport() *http.Transport {
+	c.once.Do(func() {
+		c.transport = &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dialer := &net.Dialer{Timeout: getConnectTimeout()}
+				return dialer.DialContext(ctx, network, addr)
+			},
+			MaxIdleConns:        c.MaxIdleConns,
+			MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     c.MaxConnsPerHost,
+			IdleConnTimeout:     c.IdleConnTimeout,
+			DisableKeepAlives:   c.DisableKeepAlives,
+		}
+	})
+	return c.transport
+}
+
+// CloseIdleConnections closes any connections on this Client's pool that
+// are currently sitting idle in a "keep-alive" state. It does not
+// interrupt any requests currently in flight.
+func (c *Client) CloseIdleConnections() {
+	c.httpTransport().CloseIdleConnections()
+}
+
+// Do sends req using this Client's connection pool and waits for the
+// response.
+func (c *Client) Do(r Request) (*Response, *Error) {
+	method := r.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	reader, contentType, err := requestBody(r)
+	if err != nil {
+		return nil, &Error{err: err}
+	}
+
+	req, err := http.NewRequest(method, r.Uri, reader)
+	if err != nil {
+		return nil, &Error{err: err}
+	}
+
+	if r.QueryString != nil {
+		qs, err := encodeQueryString(r.QueryString)
+		if err != nil {
+			return nil, &Error{err: err}
+		}
+		if qs != "" {
+			if req.URL.RawQuery == "" {
+				req.URL.RawQuery = qs
+			} else {
+				req.URL.RawQuery += "&" + qs
+			}
+		}
+	}
+
+	for key, values := range r.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, cookie := range r.Cookies {
+		req.AddCookie(cookie)
+	}
+
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if r.Context == nil && r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+
+	httpClient := &http.Client{Transport: c.httpTransport(), Jar: r.CookieJar}
+	httpClient.CheckRedirect = redirectPolicy(r)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return nil, &Error{timeout: true, connectTimeout: isConnectTimeout(err), err: err}
+		case context.Canceled:
+			return nil, &Error{cancelled: true, err: err}
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, &Error{timeout: true, connectTimeout: isConnectTimeout(err), err: err}
+		}
+		return nil, &Error{err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &Error{err: err}
+	}
+
+	return &Response{
+		Body:       string(data),
+		Header:     resp.Header,
+		StatusCode: resp.StatusCode,
+		Cookies:    resp.Cookies(),
+	}, nil
+}
+
+// redirectPolicy resolves the http.Client.CheckRedirect to use for r:
+// r.RedirectPolicy verbatim if set, otherwise one derived from
+// r.MaxRedirects, otherwise nil (net/http's own default of 10).
+func redirectPolicy(r Request) func(req *http.Request, via []*http.Request) error {
+	if r.RedirectPolicy != nil {
+		return r.RedirectPolicy
+	}
+	if r.MaxRedirects == 0 {
+		return nil
+	}
+	max := r.MaxRedirects
+	return func(req *http.Request, via []*http.Request) error {
+		if max < 0 || len(via) >= max {
+			return fmt.Errorf("request: stopped after %d redirects", len(via))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package request
+
+import (
+    "testing"
+    . "github.com/onsi/gomega"
+    . "github.com/franela/goblin"
+    "net/http/httptest"
+    "net/http"
+    "net"
+    "sync/atomic"
+)
+
+func TestClient(t *testing.T) {
+    g := Goblin(t)
+
+    RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+    g.Describe("Client", func() {
+        var ts *httptest.Server
+        var newConns int32
+
+        g.Before(func() {
+            ts = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(200)
+            }))
+            ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+                if state == http.StateNew {
+                    atomic.AddInt32(&newConns, 1)
+                }
+            }
+            ts.Start()
+        })
+
+        g.After(func() {
+            ts.Close()
+        })
+
+        g.It("Should reuse idle connections across sequential requests", func() {
+            atomic.StoreInt32(&newConns, 0)
+            client := &Client{}
+
+            for i := 0; i < 5; i++ {
+                res, err := client.Do(Request{Uri: ts.URL})
+                Expect(err).Should(BeNil())
+                Expect(res.StatusCode).Should(Equal(200))
+            }
+
+            Expect(atomic.LoadInt32(&newConns)).Should(Equal(int32(1)))
+        })
+
+        g.It("Should open a new connection after CloseIdleConnections", func() {
+            atomic.StoreInt32(&newConns, 0)
+            client := &Client{}
+
+            _, err := client.Do(Request{Uri: ts.URL})
+            Expect(err).Should(BeNil())
+
+            client.CloseIdleConnections()
+
+            _, err = client.Do(Request{Uri: ts.URL})
+            Expect(err).Should(BeNil())
+
+            Expect(atomic.LoadInt32(&newConns)).Should(Equal(int32(2)))
+        })
+    })
+}
@@ -0,0 +1,362 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolStopped is returned by Queue once the pool has been told to Stop.
+var ErrPoolStopped = errors.New("request: delivery pool is stopped")
+
+// BackoffConfig describes the exponential backoff (with full jitter) used
+// between delivery retries: delay_n = min(Max, Base * Factor^n), and the
+// actual wait is a random duration in [0, delay_n).
+type BackoffConfig struct {
+	Base        time.Duration
+	Factor      float64
+	Max         time.Duration
+	MaxAttempts int
+}
+
+// DefaultBackoffConfig is used by Queue when DeliveryOptions.Backoff is the
+// zero value.
+var DefaultBackoffConfig = BackoffConfig{
+	Base:        100 * time.Millisecond,
+	Factor:      2,
+	Max:         30 * time.Second,
+	MaxAttempts: 5,
+}
+
+func (b BackoffConfig) delay(attempt int) time.Duration {
+	d := float64(b.Base) * math.Pow(b.Factor, float64(attempt))
+	if d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// DeliveryOptions configures a single Request queued on a DeliveryPool.
+type DeliveryOptions struct {
+	// TargetID groups requests so they can all be dropped together via
+	// CancelByTarget, e.g. when the recipient is known-bad.
+	TargetID string
+
+	// Backoff controls retry timing. A nil Backoff uses
+	// DefaultBackoffConfig; a non-nil Backoff is used as given, with no
+	// field defaulted: a zero Max makes every retry delay zero, and a
+	// MaxAttempts of zero or one means the request is never retried.
+	// Factor: 1 keeps the delay bound constant at Base instead of growing.
+	Backoff *BackoffConfig
+}
+
+// DeliveryResult is the final, non-retryable outcome of a queued Request.
+type DeliveryResult struct {
+	ID       string
+	Response *Response
+	Err      *Error
+	Attempts int
+}
+
+type deliveryItem struct {
+	id      string
+	req     Request
+	opts    DeliveryOptions
+	result  chan DeliveryResult
+	attempt int
+
+	mu        sync.Mutex
+	cancelled bool
+}
+
+func (item *deliveryItem) cancel() bool {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	if item.cancelled {
+		return false
+	}
+	item.cancelled = true
+	return true
+}
+
+func (item *deliveryItem) isCancelled() bool {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	return item.cancelled
+}
+
+// DeliveryPool dispatches queued Requests across a fixed number of worker
+// goroutines, retrying retryable failures with exponential backoff until
+// they succeed, are cancelled, or exhaust their attempts.
+type DeliveryPool struct {
+	queue chan *deliveryItem
+
+	nextID  int64
+	stopped chan struct{}
+	stopErr error
+	once    sync.Once
+
+	workersWG sync.WaitGroup
+	itemsWG   sync.WaitGroup
+
+	mu       sync.Mutex
+	stopping bool
+	items    map[string]*deliveryItem
+	byTarget map[string]map[string]bool
+}
+
+// NewDeliveryPool starts a DeliveryPool backed by the given number of
+// worker goroutines (at least 1).
+func NewDeliveryPool(workers int) *DeliveryPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	p := &DeliveryPool{
+		queue:    make(chan *deliveryItem, 256),
+		stopped:  make(chan struct{}),
+		items:    make(map[string]*deliveryItem),
+		byTarget: make(map[string]map[string]bool),
+	}
+	p.workersWG.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *DeliveryPool) worker() {
+	defer p.workersWG.Done()
+	for item := range p.queue {
+		p.attempt(item)
+	}
+}
+
+// Queue submits req for delivery and returns an id that can be passed to
+// Cancel. Call Result with that id to receive the final DeliveryResult.
+func (p *DeliveryPool) Queue(req Request, opts DeliveryOptions) (string, error) {
+	if opts.Backoff == nil {
+		opts.Backoff = &DefaultBackoffConfig
+	}
+
+	id := strconv.FormatInt(atomic.AddInt64(&p.nextID, 1), 10)
+	item := &deliveryItem{
+		id:     id,
+		req:    req,
+		opts:   opts,
+		result: make(chan DeliveryResult, 1),
+	}
+
+	// The stopping check and the itemsWG.Add must happen under the same
+	// lock Stop uses to flip p.stopping before it calls itemsWG.Wait and
+	// closes p.queue; otherwise a Queue call could sneak an item in after
+	// Stop has already observed the item count hit zero, sending on a
+	// queue Stop is about to (or just did) close.
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return "", ErrPoolStopped
+	}
+	p.items[id] = item
+	if opts.TargetID != "" {
+		set := p.byTarget[opts.TargetID]
+		if set == nil {
+			set = make(map[string]bool)
+			p.byTarget[opts.TargetID] = set
+		}
+		set[id] = true
+	}
+	p.itemsWG.Add(1)
+	p.mu.Unlock()
+
+	p.queue <- item
+	return id, nil
+}
+
+// Result returns the channel on which the final DeliveryResult for id will
+// be sent. The channel is closed immediately after the result is sent. ok
+// is false if id is unknown (already delivered, cancelled, or never
+// queued).
+func (p *DeliveryPool) Result(id string) (result <-chan DeliveryResult, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	item, found := p.items[id]
+	if !found {
+		return nil, false
+	}
+	return item.result, true
+}
+
+// Cancel drops id if it is still queued or waiting on a backoff timer. It
+// has no effect on an attempt already in flight.
+func (p *DeliveryPool) Cancel(id string) {
+	p.mu.Lock()
+	item := p.items[id]
+	p.mu.Unlock()
+	if item != nil {
+		item.cancel()
+	}
+}
+
+// CancelByTarget cancels every still-queued item with the given TargetID.
+func (p *DeliveryPool) CancelByTarget(targetID string) {
+	p.mu.Lock()
+	ids := make([]string, 0, len(p.byTarget[targetID]))
+	for id := range p.byTarget[targetID] {
+		ids = append(ids, id)
+	}
+	p.mu.Unlock()
+
+	for _, id := range ids {
+		p.Cancel(id)
+	}
+}
+
+// Stop stops accepting new work and waits for queued and in-flight
+// deliveries to finish (or be cancelled) before returning. It returns
+// ctx.Err() if ctx is done first.
+func (p *DeliveryPool) Stop(ctx context.Context) error {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.stopping = true
+		p.mu.Unlock()
+		close(p.stopped)
+	})
+
+	drained := make(chan struct{})
+	go func() {
+		p.itemsWG.Wait()
+		close(p.queue)
+		p.workersWG.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *DeliveryPool) attempt(item *deliveryItem) {
+	if item.isCancelled() {
+		p.finish(item, DeliveryResult{
+			ID:       item.id,
+			Attempts: item.attempt,
+			Err:      &Error{cancelled: true, err: errors.New("request: cancelled before delivery")},
+		})
+		return
+	}
+
+	item.attempt++
+	res, err := item.req.Do()
+
+	if !isRetryable(res, err) {
+		p.finish(item, DeliveryResult{ID: item.id, Response: res, Err: err, Attempts: item.attempt})
+		return
+	}
+
+	if item.attempt >= item.opts.Backoff.MaxAttempts {
+		p.finish(item, DeliveryResult{ID: item.id, Response: res, Err: err, Attempts: item.attempt})
+		return
+	}
+
+	delay := item.opts.Backoff.delay(item.attempt - 1)
+	if res != nil {
+		if d, ok := retryAfterDelay(res.Header); ok {
+			delay = d
+		}
+	}
+	p.scheduleRetry(item, delay)
+}
+
+func (p *DeliveryPool) scheduleRetry(item *deliveryItem, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-p.stopped:
+			p.finish(item, DeliveryResult{
+				ID:       item.id,
+				Attempts: item.attempt,
+				Err:      &Error{cancelled: true, err: errors.New("request: pool stopped before retry")},
+			})
+			return
+		}
+		if item.isCancelled() {
+			p.finish(item, DeliveryResult{
+				ID:       item.id,
+				Attempts: item.attempt,
+				Err:      &Error{cancelled: true, err: errors.New("request: cancelled before delivery")},
+			})
+			return
+		}
+		p.queue <- item
+	}()
+}
+
+func (p *DeliveryPool) finish(item *deliveryItem, result DeliveryResult) {
+	p.mu.Lock()
+	delete(p.items, item.id)
+	if set := p.byTarget[item.opts.TargetID]; set != nil {
+		delete(set, item.id)
+		if len(set) == 0 {
+			delete(p.byTarget, item.opts.TargetID)
+		}
+	}
+	p.mu.Unlock()
+
+	item.result <- result
+	close(item.result)
+	p.itemsWG.Done()
+}
+
+// isRetryable decides whether a delivery attempt should be retried: network
+// failures (including connect timeouts) and 5xx/408/425/429 responses are
+// retryable; a cancelled context and other 4xx responses are terminal.
+func isRetryable(res *Response, err *Error) bool {
+	if err != nil {
+		return !err.Cancelled()
+	}
+	switch {
+	case res.StatusCode == 429, res.StatusCode == 408, res.StatusCode == 425:
+		return true
+	case res.StatusCode >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfterDelay parses a Retry-After header in either the seconds or
+// HTTP-date form described by RFC 7231.
+func retryAfterDelay(header http.Header) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,181 @@
+package request
+
+import (
+    "testing"
+    . "github.com/onsi/gomega"
+    . "github.com/franela/goblin"
+    "net/http/httptest"
+    "net/http"
+    "context"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+func TestDeliveryPool(t *testing.T) {
+    g := Goblin(t)
+
+    RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+    g.Describe("DeliveryPool", func() {
+
+        g.It("Should retry a 503 until it succeeds and report the attempt count", func() {
+            var hits int32
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                if atomic.AddInt32(&hits, 1) < 3 {
+                    w.WriteHeader(503)
+                    return
+                }
+                w.WriteHeader(200)
+            }))
+            defer ts.Close()
+
+            pool := NewDeliveryPool(2)
+            opts := DeliveryOptions{Backoff: &BackoffConfig{Base: 1 * time.Millisecond, Factor: 2, Max: 10 * time.Millisecond, MaxAttempts: 5}}
+            id, err := pool.Queue(Request{Uri: ts.URL}, opts)
+            Expect(err).Should(BeNil())
+
+            ch, ok := pool.Result(id)
+            Expect(ok).Should(BeTrue())
+
+            result := <-ch
+            Expect(result.Err).Should(BeNil())
+            Expect(result.Attempts).Should(Equal(3))
+
+            Expect(pool.Stop(context.Background())).Should(BeNil())
+        })
+
+        g.It("Should give up after MaxAttempts and surface the last error", func() {
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(500)
+            }))
+            defer ts.Close()
+
+            pool := NewDeliveryPool(1)
+            opts := DeliveryOptions{Backoff: &BackoffConfig{Base: 1 * time.Millisecond, Factor: 2, Max: 5 * time.Millisecond, MaxAttempts: 3}}
+            id, _ := pool.Queue(Request{Uri: ts.URL}, opts)
+
+            ch, _ := pool.Result(id)
+            result := <-ch
+            Expect(result.Attempts).Should(Equal(3))
+            Expect(result.Response.StatusCode).Should(Equal(500))
+
+            Expect(pool.Stop(context.Background())).Should(BeNil())
+        })
+
+        g.It("Should not retry a non-retryable 4xx response", func() {
+            var hits int32
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                atomic.AddInt32(&hits, 1)
+                w.WriteHeader(404)
+            }))
+            defer ts.Close()
+
+            pool := NewDeliveryPool(1)
+            id, _ := pool.Queue(Request{Uri: ts.URL}, DeliveryOptions{})
+            ch, _ := pool.Result(id)
+            result := <-ch
+
+            Expect(result.Attempts).Should(Equal(1))
+            Expect(atomic.LoadInt32(&hits)).Should(Equal(int32(1)))
+
+            Expect(pool.Stop(context.Background())).Should(BeNil())
+        })
+
+        g.It("Should cap BackoffConfig.delay at Max regardless of attempt", func() {
+            b := BackoffConfig{Base: 10 * time.Millisecond, Factor: 10, Max: 25 * time.Millisecond}
+            for attempt := 0; attempt < 4; attempt++ {
+                d := b.delay(attempt)
+                Expect(d).Should(BeNumerically(">=", 0))
+                Expect(d).Should(BeNumerically("<", b.Max))
+            }
+        })
+
+        g.It("Should honor a Retry-After header over the computed backoff", func() {
+            var hits int32
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                if atomic.AddInt32(&hits, 1) == 1 {
+                    w.Header().Set("Retry-After", "0")
+                    w.WriteHeader(503)
+                    return
+                }
+                w.WriteHeader(200)
+            }))
+            defer ts.Close()
+
+            pool := NewDeliveryPool(1)
+            // Base/Max are deliberately large: if Retry-After were ignored,
+            // the retry would take seconds and the elapsed check below
+            // would fail instead of passing by coincidence.
+            opts := DeliveryOptions{Backoff: &BackoffConfig{Base: 2 * time.Second, Factor: 2, Max: 2 * time.Second, MaxAttempts: 5}}
+
+            start := time.Now()
+            id, _ := pool.Queue(Request{Uri: ts.URL}, opts)
+            ch, _ := pool.Result(id)
+            result := <-ch
+            elapsed := time.Since(start)
+
+            Expect(result.Err).Should(BeNil())
+            Expect(result.Attempts).Should(Equal(2))
+            Expect(elapsed).Should(BeNumerically("<", 500*time.Millisecond))
+
+            Expect(pool.Stop(context.Background())).Should(BeNil())
+        })
+
+        g.It("Should drop all queued requests for a cancelled target", func() {
+            var hits int32
+            stop := make(chan bool)
+            var stopOnce sync.Once
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                atomic.AddInt32(&hits, 1)
+                <- stop
+                w.WriteHeader(200)
+            }))
+            defer ts.Close()
+            defer stopOnce.Do(func() { close(stop) })
+
+            pool := NewDeliveryPool(1)
+            opts := DeliveryOptions{TargetID: "bad-host", Backoff: &BackoffConfig{Base: time.Millisecond, Factor: 2, Max: 5 * time.Millisecond, MaxAttempts: 5}}
+
+            // The first request occupies the single worker; the rest sit queued
+            // behind it and should be dropped by CancelByTarget before they run.
+            first, _ := pool.Queue(Request{Uri: ts.URL, Timeout: 50 * time.Millisecond}, opts)
+            second, _ := pool.Queue(Request{Uri: ts.URL}, opts)
+            third, _ := pool.Queue(Request{Uri: ts.URL}, opts)
+
+            pool.CancelByTarget("bad-host")
+            stopOnce.Do(func() { close(stop) })
+
+            for _, id := range []string{first, second, third} {
+                ch, ok := pool.Result(id)
+                if !ok {
+                    continue
+                }
+                result := <-ch
+                if id != first {
+                    Expect(result.Err).ShouldNot(BeNil())
+                    Expect(result.Err.Cancelled()).Should(BeTrue())
+                }
+            }
+
+            Expect(pool.Stop(context.Background())).Should(BeNil())
+        })
+
+        g.It("Should drain in-flight work on Stop", func() {
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(200)
+            }))
+            defer ts.Close()
+
+            pool := NewDeliveryPool(2)
+            id, _ := pool.Queue(Request{Uri: ts.URL}, DeliveryOptions{})
+
+            err := pool.Stop(context.Background())
+            Expect(err).Should(BeNil())
+
+            ch, ok := pool.Result(id)
+            Expect(ok).Should(BeFalse())
+            _ = ch
+        })
+    })
+}
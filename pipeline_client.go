@@ -0,0 +1,318 @@
+package request
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PipelineConfig configures a PipelineClient.
+type PipelineConfig struct {
+	// Host is the "host:port" all requests are sent to.
+	Host string
+
+	// MaxConns bounds how many persistent connections are opened to
+	// Host. Requests are spread across them round-robin. Defaults to 1.
+	MaxConns int
+
+	// MaxPendingRequests bounds how many requests may be written to a
+	// connection awaiting their response before Do blocks. Defaults to
+	// 1024.
+	MaxPendingRequests int
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// PipelineClient holds a bounded pool of persistent connections to a
+// single host and writes requests onto the wire without waiting for prior
+// responses, reading each connection's responses back in FIFO order.
+// Borrowed from fasthttp's PipelineClient; useful when talking to a single
+// high-throughput upstream that supports HTTP/1.1 pipelining.
+type PipelineClient struct {
+	cfg PipelineConfig
+
+	mu     sync.Mutex
+	conns  []*pipelineConn
+	rr     uint64
+	closed bool
+}
+
+// NewPipelineClient starts a PipelineClient for cfg. Connections are
+// opened lazily, on first use.
+func NewPipelineClient(cfg PipelineConfig) *PipelineClient {
+	if cfg.MaxConns <= 0 {
+		cfg.MaxConns = 1
+	}
+	if cfg.MaxPendingRequests <= 0 {
+		cfg.MaxPendingRequests = 1024
+	}
+	return &PipelineClient{
+		cfg:   cfg,
+		conns: make([]*pipelineConn, cfg.MaxConns),
+	}
+}
+
+// Do sends r over one of the pool's connections and waits for its
+// response. Safe for concurrent callers. Non-idempotent requests (a
+// method other than GET/HEAD/OPTIONS/TRACE with a non-nil Body) are
+// serialized on their connection so a broken pipeline can never be
+// mistaken for a partial write of one of them.
+func (pc *PipelineClient) Do(r Request) (*Response, *Error) {
+	method := r.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	reader, contentType, err := requestBody(r)
+	if err != nil {
+		return nil, &Error{err: err}
+	}
+
+	req, err := http.NewRequest(method, r.Uri, reader)
+	if err != nil {
+		return nil, &Error{err: err}
+	}
+	req.Host = pc.cfg.Host
+	req.URL.Scheme = "http"
+	req.URL.Host = pc.cfg.Host
+
+	for key, values := range r.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Connection", "keep-alive")
+
+	idx := int(atomic.AddUint64(&pc.rr, 1)-1) % len(pc.conns)
+	conn, err := pc.connAt(idx)
+	if err != nil {
+		return nil, &Error{err: err}
+	}
+
+	return conn.send(req, !isIdempotent(method, r.Body))
+}
+
+// Close marks pc closed and tears down every connection in the pool.
+// Requests currently in-flight fail with a retryable error; already
+// delivered responses are unaffected. Once Close returns, any Do call
+// racing with it (or arriving afterwards) is rejected by connAt/ensureConn
+// instead of dialing a fresh connection that Close has no way to find.
+func (pc *PipelineClient) Close() error {
+	pc.mu.Lock()
+	pc.closed = true
+	conns := pc.conns
+	pc.mu.Unlock()
+
+	for i, c := range conns {
+		if c == nil {
+			continue
+		}
+		c.closeWith(errors.New("request: pipeline client closed"))
+		conns[i] = nil
+	}
+	return nil
+}
+
+var errPipelineClientClosed = errors.New("request: pipeline client closed")
+
+func (pc *PipelineClient) connAt(idx int) (*pipelineConn, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.closed {
+		return nil, errPipelineClientClosed
+	}
+	if pc.conns[idx] == nil {
+		pc.conns[idx] = &pipelineConn{client: pc}
+	}
+	return pc.conns[idx], nil
+}
+
+func isIdempotent(method string, body interface{}) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "":
+		return true
+	default:
+		return body == nil
+	}
+}
+
+// pipelineRequest is one write/read pair in flight on a pipelineConn.
+type pipelineRequest struct {
+	req    *http.Request
+	result chan pipelineResult
+}
+
+type pipelineResult struct {
+	resp *Response
+	err  *Error
+}
+
+// pipelineConn is one persistent connection: requests are written under
+// writeMu (which also guards conn/pending/closed), then handed to a
+// dedicated goroutine that reads their responses back in the same order
+// they were written.
+type pipelineConn struct {
+	client *PipelineClient
+
+	writeMu sync.Mutex
+	conn    net.Conn
+	pending chan *pipelineRequest
+	closed  chan struct{}
+}
+
+func (c *pipelineConn) ensureConn() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn != nil {
+		return nil
+	}
+
+	c.client.mu.Lock()
+	closed := c.client.closed
+	c.client.mu.Unlock()
+	if closed {
+		return errPipelineClientClosed
+	}
+
+	conn, err := net.Dial("tcp", c.client.cfg.Host)
+	if err != nil {
+		return err
+	}
+
+	c.conn = conn
+	c.pending = make(chan *pipelineRequest, c.client.cfg.MaxPendingRequests)
+	c.closed = make(chan struct{})
+	go c.readLoop(conn, c.pending, c.closed)
+	return nil
+}
+
+func (c *pipelineConn) send(req *http.Request, serial bool) (*Response, *Error) {
+	if err := c.ensureConn(); err != nil {
+		return nil, &Error{err: err}
+	}
+
+	pr := &pipelineRequest{req: req, result: make(chan pipelineResult, 1)}
+
+	c.writeMu.Lock()
+	conn, pending, closed := c.conn, c.pending, c.closed
+	if conn == nil {
+		c.writeMu.Unlock()
+		return nil, &Error{err: errors.New("request: pipeline connection closed")}
+	}
+	if c.client.cfg.WriteTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(c.client.cfg.WriteTimeout))
+	}
+
+	writeErr := req.Write(conn)
+	if writeErr == nil {
+		pending <- pr
+	}
+	if !serial || writeErr != nil {
+		c.writeMu.Unlock()
+	}
+
+	if writeErr != nil {
+		c.teardown(conn, pending, closed, writeErr)
+		return nil, &Error{err: writeErr}
+	}
+
+	result := <-pr.result
+	if serial {
+		c.writeMu.Unlock()
+	}
+	return result.resp, result.err
+}
+
+func (c *pipelineConn) readLoop(conn net.Conn, pending chan *pipelineRequest, closed chan struct{}) {
+	br := bufio.NewReader(conn)
+	for {
+		var pr *pipelineRequest
+		select {
+		case pr = <-pending:
+		case <-closed:
+			return
+		}
+
+		if c.client.cfg.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(c.client.cfg.ReadTimeout))
+		}
+
+		resp, err := http.ReadResponse(br, pr.req)
+		if err != nil {
+			pr.result <- pipelineResult{err: &Error{err: err}}
+			close(pr.result)
+			c.teardown(conn, pending, closed, err)
+			return
+		}
+
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			pr.result <- pipelineResult{err: &Error{err: err}}
+			close(pr.result)
+			c.teardown(conn, pending, closed, err)
+			return
+		}
+
+		pr.result <- pipelineResult{resp: &Response{
+			Body:       string(data),
+			Header:     resp.Header,
+			StatusCode: resp.StatusCode,
+			Cookies:    resp.Cookies(),
+		}}
+		close(pr.result)
+	}
+}
+
+// teardown closes conn and fails every request still waiting on it with a
+// retryable *Error. conn/pending/closed are the values this goroutine
+// observed when it started the write or read that failed. A write failure
+// (from send, any caller goroutine) and a read failure (from readLoop, the
+// connection's own goroutine) can both race to tear down the same
+// generation, so only the goroutine that wins the compare-and-clear of
+// c.conn actually closes anything; the loser returns immediately.
+func (c *pipelineConn) teardown(conn net.Conn, pending chan *pipelineRequest, closed chan struct{}, cause error) {
+	c.writeMu.Lock()
+	owns := c.conn == conn
+	if owns {
+		c.conn = nil
+	}
+	c.writeMu.Unlock()
+
+	if !owns {
+		return
+	}
+
+	conn.Close()
+	close(closed)
+
+	for {
+		select {
+		case pr := <-pending:
+			pr.result <- pipelineResult{err: &Error{err: cause}}
+			close(pr.result)
+		default:
+			return
+		}
+	}
+}
+
+func (c *pipelineConn) closeWith(cause error) {
+	c.writeMu.Lock()
+	conn, pending, closed := c.conn, c.pending, c.closed
+	c.writeMu.Unlock()
+	if conn == nil {
+		return
+	}
+	c.teardown(conn, pending, closed, cause)
+}
@@ -0,0 +1,112 @@
+package request
+
+import (
+    "testing"
+    . "github.com/onsi/gomega"
+    . "github.com/franela/goblin"
+    "net/http/httptest"
+    "net/http"
+    "fmt"
+    "strconv"
+    "sync"
+    "time"
+)
+
+func TestPipelineClient(t *testing.T) {
+    g := Goblin(t)
+
+    RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+    g.Describe("PipelineClient", func() {
+        g.It("Should read back a burst of responses in request order", func() {
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                fmt.Fprint(w, r.URL.Query().Get("id"))
+            }))
+            defer ts.Close()
+
+            pc := NewPipelineClient(PipelineConfig{Host: ts.Listener.Addr().String(), MaxConns: 4})
+            defer pc.Close()
+
+            const n = 200
+            var wg sync.WaitGroup
+            wg.Add(n)
+            for i := 0; i < n; i++ {
+                go func(i int) {
+                    defer wg.Done()
+                    res, err := pc.Do(Request{Uri: "http://" + ts.Listener.Addr().String() + "/?id=" + strconv.Itoa(i)})
+                    Expect(err).Should(BeNil())
+                    Expect(res.Body).Should(Equal(strconv.Itoa(i)))
+                }(i)
+            }
+            wg.Wait()
+        })
+
+        g.It("Should surface a retryable error to every pending request when a connection dies", func() {
+            stop := make(chan struct{})
+            ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                <-stop
+            }))
+            defer func() { close(stop); ts.Close() }()
+
+            pc := NewPipelineClient(PipelineConfig{Host: ts.Listener.Addr().String(), MaxConns: 1})
+            defer pc.Close()
+
+            var wg sync.WaitGroup
+            errs := make([]*Error, 5)
+            for i := 0; i < 5; i++ {
+                wg.Add(1)
+                go func(i int) {
+                    defer wg.Done()
+                    _, err := pc.Do(Request{Uri: "http://" + ts.Listener.Addr().String()})
+                    errs[i] = err
+                }(i)
+            }
+
+            time.Sleep(50 * time.Millisecond)
+            pc.Close()
+            wg.Wait()
+
+            for _, err := range errs {
+                Expect(err).ShouldNot(BeNil())
+            }
+        })
+    })
+}
+
+func BenchmarkPipelineClient(b *testing.B) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(200)
+    }))
+    defer ts.Close()
+
+    pc := NewPipelineClient(PipelineConfig{Host: ts.Listener.Addr().String(), MaxConns: 8})
+    defer pc.Close()
+
+    b.ResetTimer()
+    b.RunParallel(func(pb *testing.PB) {
+        for pb.Next() {
+            if _, err := pc.Do(Request{Uri: ts.URL}); err != nil {
+                b.Fatal(err)
+            }
+        }
+    })
+}
+
+func BenchmarkClientDo(b *testing.B) {
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(200)
+    }))
+    defer ts.Close()
+
+    client := &Client{MaxIdleConnsPerHost: 8}
+    defer client.CloseIdleConnections()
+
+    b.ResetTimer()
+    b.RunParallel(func(pb *testing.PB) {
+        for pb.Next() {
+            if _, err := client.Do(Request{Uri: ts.URL}); err != nil {
+                b.Fatal(err)
+            }
+        }
+    })
+}
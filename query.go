@@ -0,0 +1,75 @@
+package request
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+// encodeQueryString turns a Request.QueryString value into a URL-encoded
+// query string. Supported inputs are url.Values, map[string]string,
+// map[string]interface{}, and structs (or pointers to structs), whose
+// exported fields are encoded using their `url` tag or field name.
+func encodeQueryString(qs interface{}) (string, error) {
+	switch v := qs.(type) {
+	case nil:
+		return "", nil
+	case url.Values:
+		return v.Encode(), nil
+	case map[string]string:
+		values := url.Values{}
+		for k, val := range v {
+			values.Set(k, val)
+		}
+		return values.Encode(), nil
+	case map[string]interface{}:
+		values := url.Values{}
+		for k, val := range v {
+			values.Set(k, fmt.Sprintf("%v", val))
+		}
+		return values.Encode(), nil
+	default:
+		return encodeStructQueryString(v)
+	}
+}
+
+func encodeStructQueryString(qs interface{}) (string, error) {
+	val := reflect.ValueOf(qs)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return "", nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return "", fmt.Errorf("request: QueryString must be a map, url.Values, or struct, got %T", qs)
+	}
+
+	values := url.Values{}
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+		if !fieldVal.CanInterface() {
+			continue // unexported field
+		}
+
+		name := field.Tag.Get("url")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if fieldVal.Kind() == reflect.Ptr {
+			if fieldVal.IsNil() {
+				continue
+			}
+			fieldVal = fieldVal.Elem()
+		}
+
+		values.Set(name, fmt.Sprintf("%v", fieldVal.Interface()))
+	}
+	return values.Encode(), nil
+}
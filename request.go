@@ -0,0 +1,158 @@
+// Package request is a small wrapper around net/http that makes one-off
+// HTTP calls easy to express as a single value: build a Request, call Do,
+// get back a Response or a typed Error.
+package request
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	mutex          sync.Mutex
+	connectTimeout = 1000 * time.Millisecond
+)
+
+// SetConnectTimeout changes how long Do waits for the TCP connection to be
+// established before giving up. It defaults to 1000ms and applies to every
+// Request made afterwards.
+func SetConnectTimeout(duration time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	connectTimeout = duration
+}
+
+func getConnectTimeout() time.Duration {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return connectTimeout
+}
+
+// Request describes a single HTTP call. The zero value performs a GET.
+type Request struct {
+	Method  string
+	Uri     string
+	Body    interface{}
+	Timeout time.Duration
+
+	// Context, if set, governs cancellation of the request in place of
+	// Timeout. Use WithContext to derive a Request that carries one.
+	Context context.Context
+
+	// Headers are added to the request in addition to whatever Body sets,
+	// e.g. Content-Type.
+	Headers http.Header
+
+	// QueryString is encoded onto the request URL, in addition to any
+	// query already present in Uri. It may be a url.Values, a
+	// map[string]string, a map[string]interface{}, or a struct (encoded
+	// via its exported fields, optionally tagged `url:"name"`).
+	QueryString interface{}
+
+	// Cookies are attached to the request via http.Request.AddCookie.
+	Cookies []*http.Cookie
+
+	// CookieJar, if set, is used by the underlying http.Client, so
+	// cookies set by the server are stored and replayed on later requests
+	// that share the same jar.
+	CookieJar http.CookieJar
+
+	// MaxRedirects bounds how many redirects Do will follow. Zero means
+	// use net/http's default (10); negative disables redirects entirely.
+	// Ignored when RedirectPolicy is set.
+	MaxRedirects int
+
+	// RedirectPolicy, if set, is used as the underlying http.Client's
+	// CheckRedirect, overriding MaxRedirects.
+	RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+	// ContentType selects how Body is encoded: "" JSON-encodes anything
+	// that isn't already a string or io.Reader (the default),
+	// ContentTypeForm URL-encodes it, and ContentTypeMultipart writes it
+	// (plus Files) as multipart/form-data. AsForm and AsMultipart set
+	// this for you.
+	ContentType string
+
+	// Files are written as additional multipart/form-data parts when
+	// ContentType is ContentTypeMultipart. Ignored otherwise.
+	Files []FileField
+}
+
+// WithContext returns a shallow copy of r with its Context set to ctx. It
+// panics if ctx is nil, matching http.Request.WithContext.
+func (r Request) WithContext(ctx context.Context) Request {
+	if ctx == nil {
+		panic("request: nil Context")
+	}
+	r.Context = ctx
+	return r
+}
+
+// Response is what Do returns on success. Body is fully read into memory.
+type Response struct {
+	Body       string
+	Header     http.Header
+	StatusCode int
+
+	// Cookies are whatever cookies the server set on the response, read
+	// regardless of whether a CookieJar was configured on the Request.
+	Cookies []*http.Cookie
+}
+
+// Error is returned by Do instead of the standard error interface so callers
+// can tell timeouts apart from other failures.
+type Error struct {
+	timeout        bool
+	connectTimeout bool
+	cancelled      bool
+	err            error
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Timeout reports whether the request failed because it ran out of time,
+// either while connecting or while waiting for the response.
+func (e *Error) Timeout() bool {
+	return e.timeout
+}
+
+// ConnectTimeout reports whether the request failed because a connection
+// could not be established within the configured connect timeout.
+func (e *Error) ConnectTimeout() bool {
+	return e.connectTimeout
+}
+
+// RequestTimeout reports whether the request failed because it did not
+// complete within Request.Timeout or before its Context's deadline.
+func (e *Error) RequestTimeout() bool {
+	return e.timeout && !e.connectTimeout
+}
+
+// Cancelled reports whether the request failed because its Context was
+// cancelled (as opposed to its deadline expiring, see RequestTimeout).
+func (e *Error) Cancelled() bool {
+	return e.cancelled
+}
+
+// Do sends the request and waits for the response. The returned *Error (not
+// a plain error) lets callers ask whether the failure was a timeout. It is
+// a convenience for DefaultClient.Do; use a Client directly to isolate or
+// reuse a connection pool across calls.
+func (r Request) Do() (*Response, *Error) {
+	return DefaultClient.Do(r)
+}
+
+// isConnectTimeout tells a dial timeout (the TCP handshake never completed)
+// apart from a timeout that hit after the connection was already open.
+// http.Client.Do wraps transport errors in *url.Error, so the *net.OpError
+// has to be found with errors.As rather than a direct type assertion.
+func isConnectTimeout(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
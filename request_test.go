@@ -6,6 +6,7 @@ import (
     . "github.com/franela/goblin"
     "net/http/httptest"
     "net/http"
+    "context"
     "fmt"
     "strings"
     "time"
@@ -187,8 +188,108 @@ func TestRequest(t *testing.T) {
             })
         })
 
-        g.Describe("Misc", func() {
-            g.It("Should offer to set request headers")
+        g.Describe("Context", func() {
+            var ts *httptest.Server
+            stop := make(chan bool)
+
+            g.Before(func() {
+                ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                    <- stop
+                }))
+            })
+            g.After(func() {
+                close(stop)
+                ts.Close()
+            })
+
+            g.It("Should cancel the request when the context is cancelled", func() {
+                ctx, cancel := context.WithCancel(context.Background())
+
+                go func() {
+                    time.Sleep(50 * time.Millisecond)
+                    cancel()
+                }()
+
+                res, err := Request{ Uri: ts.URL }.WithContext(ctx).Do()
+
+                Expect(res).Should(BeNil())
+                Expect(err.Cancelled()).Should(BeTrue())
+                Expect(err.RequestTimeout()).Should(BeFalse())
+            })
+
+            g.It("Should report a deadline exceeded context as a request timeout", func() {
+                ctx, cancel := context.WithTimeout(context.Background(), 50 * time.Millisecond)
+                defer cancel()
+
+                res, err := Request{ Uri: ts.URL }.WithContext(ctx).Do()
+
+                Expect(res).Should(BeNil())
+                Expect(err.RequestTimeout()).Should(BeTrue())
+                Expect(err.Cancelled()).Should(BeFalse())
+            })
+        })
+
+        g.Describe("Headers, query string, cookies and redirects", func() {
+            var ts *httptest.Server
+
+            g.Before(func() {
+                ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                    if r.URL.Path == "/redirect" {
+                        http.Redirect(w, r, "/redirected", 302)
+                        return
+                    }
+                    if r.URL.Path == "/set-cookie" {
+                        http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+                        w.WriteHeader(200)
+                        return
+                    }
+                    w.Header().Set("X-Echo-Header", r.Header.Get("X-Request-Id"))
+                    fmt.Fprint(w, r.URL.RawQuery)
+                }))
+            })
+
+            g.After(func() {
+                ts.Close()
+            })
+
+            g.It("Should offer to set request headers", func() {
+                res, err := Request{ Uri: ts.URL, Headers: http.Header{"X-Request-Id": []string{"42"}} }.Do()
+
+                Expect(err).Should(BeNil())
+                Expect(res.Header.Get("X-Echo-Header")).Should(Equal("42"))
+            })
+
+            g.It("Should encode a map QueryString", func() {
+                res, err := Request{ Uri: ts.URL, QueryString: map[string]string{"foo": "bar"} }.Do()
+
+                Expect(err).Should(BeNil())
+                Expect(res.Body).Should(Equal("foo=bar"))
+            })
+
+            g.It("Should encode a struct QueryString", func() {
+                type params struct {
+                    Foo string `url:"foo"`
+                }
+                res, err := Request{ Uri: ts.URL, QueryString: params{Foo: "bar"} }.Do()
+
+                Expect(err).Should(BeNil())
+                Expect(res.Body).Should(Equal("foo=bar"))
+            })
+
+            g.It("Should surface cookies set by the server", func() {
+                res, err := Request{ Uri: ts.URL + "/set-cookie" }.Do()
+
+                Expect(err).Should(BeNil())
+                Expect(res.Cookies).Should(HaveLen(1))
+                Expect(res.Cookies[0].Value).Should(Equal("abc123"))
+            })
+
+            g.It("Should refuse to follow a redirect when MaxRedirects is negative", func() {
+                res, err := Request{ Uri: ts.URL + "/redirect", MaxRedirects: -1 }.Do()
+
+                Expect(res).Should(BeNil())
+                Expect(err).ShouldNot(BeNil())
+            })
         })
     })
 }